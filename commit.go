@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commitKeymap exposes the commit screen's bindings through bubbles/key
+// so the same set renders in both short and long help views.
+type commitKeymap struct {
+	Submit key.Binding
+	Cancel key.Binding
+}
+
+func newCommitKeymap() commitKeymap {
+	return commitKeymap{
+		Submit: key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "commit")),
+		Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	}
+}
+
+func (k commitKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Submit, k.Cancel}
+}
+
+func (k commitKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Submit, k.Cancel}}
+}
+
+const (
+	subjectSoftLimit = 50
+	subjectHardLimit = 72
+)
+
+// commitModel is the full-screen commit composer reached from the list
+// with 'c' (or 'C' to amend).
+type commitModel struct {
+	ta     textarea.Model
+	help   help.Model
+	keys   commitKeymap
+	amend  bool
+	staged []fileChange
+	err    error
+}
+
+func newCommitModel(staged []fileChange, amend bool) (*commitModel, error) {
+	ta := textarea.New()
+	ta.Placeholder = "Subject\n\nBody (optional)"
+	ta.ShowLineNumbers = false
+	ta.Focus()
+	if amend {
+		msg, err := run("git", "log", "-1", "--pretty=%B")
+		if err != nil {
+			return nil, err
+		}
+		ta.SetValue(strings.TrimRight(msg, "\n"))
+	}
+	return &commitModel{ta: ta, help: help.New(), keys: newCommitKeymap(), amend: amend, staged: staged}, nil
+}
+
+// handleKey feeds msg to the textarea unless it's a screen-level binding;
+// done reports the screen should close, committed whether it closed via
+// submit (vs. cancel).
+func (m *commitModel) handleKey(msg tea.KeyMsg) (done bool, committed bool) {
+	switch msg.String() {
+	case "ctrl+s":
+		return true, true
+	case "esc":
+		return true, false
+	}
+	var cmd tea.Cmd
+	m.ta, cmd = m.ta.Update(msg)
+	_ = cmd
+	return false, false
+}
+
+// commit runs `git commit -F -` (or `--amend -F -`), piping the
+// textarea's contents via stdin.
+func (m *commitModel) commit() error {
+	args := []string{"commit", "-F", "-"}
+	if m.amend {
+		args = []string{"commit", "--amend", "-F", "-"}
+	}
+	_, err := runWithStdin(m.ta.Value(), "git", args...)
+	return err
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func (m *commitModel) View() string {
+	var b strings.Builder
+	title := "Commit"
+	if m.amend {
+		title = "Commit (amending)"
+	}
+	b.WriteString(titleStyle.Render(title) + "\n\n")
+	b.WriteString(m.ta.View())
+	b.WriteString("\n")
+
+	n := len(firstLine(m.ta.Value()))
+	countLine := fmt.Sprintf("subject: %d cols", n)
+	switch {
+	case n > subjectHardLimit:
+		b.WriteString(errorStyle.Render(countLine + " (over " + fmt.Sprint(subjectHardLimit) + ")"))
+	case n > subjectSoftLimit:
+		b.WriteString(legendStyle.Render(countLine + " (over " + fmt.Sprint(subjectSoftLimit) + ")"))
+	default:
+		b.WriteString(legendStyle.Render(countLine))
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Staged files (%d):", len(m.staged))) + "\n")
+	for _, f := range m.staged {
+		b.WriteString("  " + f.Path + "\n")
+	}
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n\n")
+	}
+	b.WriteString(m.help.View(m.keys))
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/muesli/termenv"
+)
+
+// statModTime returns the file's mtime in unix nanoseconds, or 0 if it
+// can't be stat'd (e.g. a staged deletion no longer on disk).
+func statModTime(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
+
+// diffCacheKey identifies the inputs that can change a file's rendered
+// diff, so moving the list cursor doesn't re-run git every frame.
+type diffCacheKey struct {
+	path     string
+	index    rune
+	worktree rune
+	modTime  int64
+}
+
+type diffCache struct {
+	key     diffCacheKey
+	content string
+}
+
+// loadDiff shells out to git for the colorized unstaged + staged diff of
+// path, concatenating both with section headers.
+func loadDiff(path string) (string, error) {
+	profile := termenv.EnvColorProfile()
+	var b strings.Builder
+
+	unstaged, err := run("git", "diff", "--color=always", "--", path)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(unstaged) != "" {
+		b.WriteString(titleStyle.Render("── unstaged ──") + "\n")
+		b.WriteString(degradeANSI(unstaged, profile))
+		b.WriteString("\n")
+	}
+
+	staged, err := run("git", "diff", "--cached", "--color=always", "--", path)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(staged) != "" {
+		b.WriteString(titleStyle.Render("── staged ──") + "\n")
+		b.WriteString(degradeANSI(staged, profile))
+	}
+
+	out := b.String()
+	if strings.TrimSpace(out) == "" {
+		return legendStyle.Render(fmt.Sprintf("(no diff for %s)", path)), nil
+	}
+	return out, nil
+}
+
+var ansiSGRRe = regexp.MustCompile("\x1b\\[([0-9;]*)m")
+
+// degradeANSI rewrites the SGR color escapes git emits for
+// `--color=always` down to whatever color profile the terminal actually
+// supports, via termenv, so truecolor/256-color codes degrade gracefully
+// (or disappear entirely under NO_COLOR) instead of showing up as
+// garbage on a 16-color terminal.
+func degradeANSI(s string, profile termenv.Profile) string {
+	if profile == termenv.TrueColor {
+		return s
+	}
+	return ansiSGRRe.ReplaceAllStringFunc(s, func(seq string) string {
+		codes := strings.Split(ansiSGRRe.FindStringSubmatch(seq)[1], ";")
+		var out []string
+		for i := 0; i < len(codes); i++ {
+			switch code := codes[i]; {
+			case (code == "38" || code == "48") && i+2 < len(codes) && codes[i+1] == "5":
+				out = appendDegradedColor(out, profile, code == "48", termenv.ANSI256Color(atoiOr(codes[i+2], 0)))
+				i += 2
+			case (code == "38" || code == "48") && i+4 < len(codes) && codes[i+1] == "2":
+				out = appendDegradedColor(out, profile, code == "48", termenv.RGBColor(rgbHex(codes[i+2], codes[i+3], codes[i+4])))
+				i += 4
+			case code != "":
+				// Under Ascii (NO_COLOR) the basic foreground/background
+				// color codes go too; attributes such as bold are kept.
+				if n := atoiOr(code, -1); profile == termenv.Ascii && ((n >= 30 && n <= 49) || (n >= 90 && n <= 107)) {
+					continue
+				}
+				out = append(out, code)
+			}
+		}
+		if len(out) == 0 {
+			return ""
+		}
+		return "\x1b[" + strings.Join(out, ";") + "m"
+	})
+}
+
+// appendDegradedColor converts c to profile and appends its SGR
+// sub-codes to out, dropping it entirely if profile has no color
+// (Ascii/NO_COLOR).
+func appendDegradedColor(out []string, profile termenv.Profile, bg bool, c termenv.Color) []string {
+	seq := profile.Convert(c).Sequence(bg)
+	if seq == "" {
+		return out
+	}
+	return append(out, strings.Split(seq, ";")...)
+}
+
+func rgbHex(r, g, b string) string {
+	return fmt.Sprintf("#%02x%02x%02x", atoiOr(r, 0), atoiOr(g, 0), atoiOr(b, 0))
+}
+
+// diffViewport wraps a bubbles/viewport.Model plus the cache key of the
+// content currently loaded into it.
+type diffViewport struct {
+	vp      viewport.Model
+	cache   diffCache
+	visible bool
+}
+
+func newDiffViewport() diffViewport {
+	return diffViewport{vp: viewport.New(0, 0)}
+}
+
+// loadFor refreshes the viewport's content if f has changed since the
+// last render (path, index/worktree status, or mtime).
+func (d *diffViewport) loadFor(f *fileChange) {
+	if f == nil {
+		d.vp.SetContent(legendStyle.Render("(no file selected)"))
+		d.cache = diffCache{}
+		return
+	}
+	key := diffCacheKey{path: f.Path, index: f.Index, worktree: f.Worktree, modTime: statModTime(f.Path)}
+	if key == d.cache.key {
+		return
+	}
+	content, err := loadDiff(f.Path)
+	if err != nil {
+		content = errorStyle.Render(err.Error())
+	}
+	d.vp.SetContent(content)
+	d.cache = diffCache{key: key, content: content}
+}
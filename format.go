@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DireLines/gitadd/internal/status"
+)
+
+// jsonFile mirrors status.File with stable, lowercase field names for
+// the --format=json output.
+type jsonFile struct {
+	Path     string `json:"path"`
+	Index    string `json:"index"`
+	Worktree string `json:"worktree"`
+	Added    int    `json:"added"`
+	Deleted  int    `json:"deleted"`
+	Binary   bool   `json:"binary"`
+}
+
+type jsonSummary struct {
+	Added   int `json:"added"`
+	Deleted int `json:"deleted"`
+	Files   int `json:"files"`
+}
+
+type jsonOutput struct {
+	Files   []jsonFile  `json:"files"`
+	Summary jsonSummary `json:"summary"`
+}
+
+// writeFormatted renders files to w in the requested non-interactive
+// format, for shell integrations that want gitadd's merged status+
+// numstat view without re-running git status/diff themselves.
+func writeFormatted(w io.Writer, format string, files []status.File) error {
+	switch format {
+	case "json":
+		return writeJSON(w, files)
+	case "porcelain":
+		writePorcelain(w, files)
+		return nil
+	case "tsv":
+		writeTSV(w, files)
+		return nil
+	default:
+		return fmt.Errorf("unknown --format %q (want json, porcelain, or tsv)", format)
+	}
+}
+
+func writeJSON(w io.Writer, files []status.File) error {
+	out := jsonOutput{Files: make([]jsonFile, len(files))}
+	for i, f := range files {
+		out.Files[i] = jsonFile{
+			Path:     f.Path,
+			Index:    string(f.Index),
+			Worktree: string(f.Worktree),
+			Added:    f.Added,
+			Deleted:  f.Deleted,
+			Binary:   f.Binary,
+		}
+		out.Summary.Added += f.Added
+		out.Summary.Deleted += f.Deleted
+	}
+	out.Summary.Files = len(files)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// writePorcelain reprints each file's original `git status --porcelain`
+// line, preserving that format for tools already parsing it.
+func writePorcelain(w io.Writer, files []status.File) {
+	for _, f := range files {
+		fmt.Fprintln(w, f.RawLine)
+	}
+}
+
+func writeTSV(w io.Writer, files []status.File) {
+	for _, f := range files {
+		binary := "false"
+		if f.Binary {
+			binary = "true"
+		}
+		fmt.Fprintln(w, strings.Join([]string{
+			f.Path,
+			string(f.Index),
+			string(f.Worktree),
+			strconv.Itoa(f.Added),
+			strconv.Itoa(f.Deleted),
+			binary,
+		}, "\t"))
+	}
+}
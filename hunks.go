@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// diffHunk is one "@@ -a,b +c,d @@" section of a unified diff, with its
+// body lines (context/+/-) kept separately from the recomputed header so
+// splitting can rebuild headers without re-parsing text.
+type diffHunk struct {
+	lines                                  []string
+	oldStart, oldLines, newStart, newLines int
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))?\s\+(\d+)(?:,(\d+))?\s@@`)
+
+// header renders the "@@ -a,b +c,d @@" line for h.
+func (h diffHunk) header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.oldStart, h.oldLines, h.newStart, h.newLines)
+}
+
+// parseHunks splits a single-file `git diff -U3` (or --cached) output into
+// its file preamble (diff --git/index/---/+++ lines) and hunks.
+func parseHunks(diffText string) (preamble string, hunks []diffHunk) {
+	lines := strings.Split(strings.TrimRight(diffText, "\n"), "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		if hunkHeaderRe.MatchString(lines[i]) {
+			break
+		}
+	}
+	preamble = strings.Join(lines[:i], "\n")
+	if preamble != "" {
+		preamble += "\n"
+	}
+
+	for i < len(lines) {
+		m := hunkHeaderRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			i++
+			continue
+		}
+		h := diffHunk{
+			oldStart: atoiOr(m[1], 1),
+			oldLines: atoiOr(m[2], 1),
+			newStart: atoiOr(m[3], 1),
+			newLines: atoiOr(m[4], 1),
+		}
+		i++
+		for i < len(lines) && !hunkHeaderRe.MatchString(lines[i]) {
+			h.lines = append(h.lines, lines[i])
+			i++
+		}
+		hunks = append(hunks, h)
+	}
+	return preamble, hunks
+}
+
+func atoiOr(s string, fallback int) int {
+	if s == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// splitHunk breaks a hunk's contiguous +/- runs (each separated by
+// context lines) into independently-selectable sub-hunks, recomputing
+// the @@ header offsets for each.
+func splitHunk(h diffHunk) []diffHunk {
+	var runs []hunkRun
+	oldLine, newLine := h.oldStart, h.newStart
+	for _, ln := range h.lines {
+		isChange := len(ln) > 0 && (ln[0] == '+' || ln[0] == '-')
+		if len(runs) == 0 || runs[len(runs)-1].hasChange != isChange {
+			runs = append(runs, hunkRun{hasChange: isChange, oldStart: oldLine, newStart: newLine})
+		}
+		r := &runs[len(runs)-1]
+		r.lines = append(r.lines, ln)
+		if len(ln) == 0 || ln[0] != '+' {
+			oldLine++
+		}
+		if len(ln) == 0 || ln[0] != '-' {
+			newLine++
+		}
+	}
+
+	if countChangeRuns(runs) <= 1 {
+		return []diffHunk{h}
+	}
+
+	var out []diffHunk
+	for i, r := range runs {
+		if !r.hasChange {
+			continue
+		}
+		var body []string
+		oldStart, newStart := r.oldStart, r.newStart
+		if i > 0 && !runs[i-1].hasChange {
+			ctx := runs[i-1].lines
+			lead := ctx
+			if i-1 > 0 {
+				// This context run sits between two change runs, so it
+				// belongs to both neighbors' sub-hunks: give the earlier
+				// sub-hunk its trailing half and leave the rest (below)
+				// for the later one, so the two don't overlap.
+				_, lead = splitContext(ctx)
+			}
+			oldStart -= len(lead)
+			newStart -= len(lead)
+			body = append(body, lead...)
+		}
+		body = append(body, r.lines...)
+		if i+1 < len(runs) && !runs[i+1].hasChange {
+			ctx := runs[i+1].lines
+			trail := ctx
+			if i+2 < len(runs) {
+				trail, _ = splitContext(ctx)
+			}
+			body = append(body, trail...)
+		}
+		sub := diffHunk{lines: body, oldStart: oldStart, newStart: newStart}
+		sub.oldLines, sub.newLines = countOldNew(body)
+		out = append(out, sub)
+	}
+	return out
+}
+
+// splitContext divides a shared context run between the sub-hunk before it
+// and the sub-hunk after it so the two don't both claim the same lines:
+// the front half stays with the earlier sub-hunk, the back half with the
+// later one.
+func splitContext(ctx []string) (front, back []string) {
+	n := (len(ctx) + 1) / 2
+	return ctx[:n], ctx[n:]
+}
+
+// hunkRun is a maximal consecutive stretch of a hunk's lines that are
+// either all context or all +/- change lines.
+type hunkRun struct {
+	lines     []string
+	hasChange bool
+	oldStart  int
+	newStart  int
+}
+
+func countChangeRuns(runs []hunkRun) int {
+	n := 0
+	for _, r := range runs {
+		if r.hasChange {
+			n++
+		}
+	}
+	return n
+}
+
+func countOldNew(lines []string) (old, new int) {
+	for _, ln := range lines {
+		if len(ln) == 0 || ln[0] != '+' {
+			old++
+		}
+		if len(ln) == 0 || ln[0] != '-' {
+			new++
+		}
+	}
+	return old, new
+}
+
+// buildPatch reconstructs a complete unified-diff patch from the file
+// preamble and a set of (possibly split) hunks.
+func buildPatch(preamble string, hunks []diffHunk) string {
+	var b strings.Builder
+	b.WriteString(preamble)
+	for _, h := range hunks {
+		b.WriteString(h.header())
+		b.WriteString("\n")
+		for _, ln := range h.lines {
+			b.WriteString(ln)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// applyPatch feeds patch to `git apply --cached`, reversed when
+// unstaging a hunk that's currently staged.
+func applyPatch(patch string, reverse bool) error {
+	args := []string{"apply", "--cached"}
+	if reverse {
+		args = append(args, "--reverse")
+	}
+	args = append(args, "-")
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = strings.NewReader(patch)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %v\n%s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// hunkPickerModel is a standalone bubbletea program that walks the user
+// through a file's hunks one at a time with y/n/s/q.
+type hunkPickerModel struct {
+	path     string
+	unstage  bool // true: picking from the staged diff, to unstage hunks
+	preamble string
+	queue    []diffHunk
+	pos      int
+	chosen   []diffHunk
+	quit     bool
+}
+
+func newHunkPicker(path string, unstage bool) (*hunkPickerModel, error) {
+	var out string
+	var err error
+	if unstage {
+		out, err = run("git", "diff", "--cached", "-U3", "--", path)
+	} else {
+		out, err = run("git", "diff", "-U3", "--", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	preamble, hunks := parseHunks(out)
+	return &hunkPickerModel{path: path, unstage: unstage, preamble: preamble, queue: hunks}, nil
+}
+
+// handleKey advances the picker in response to a keypress and reports
+// whether the picker has finished (ran out of hunks, or user quit).
+func (m *hunkPickerModel) handleKey(key string) (finished bool) {
+	if m.pos >= len(m.queue) {
+		return true
+	}
+	switch key {
+	case "y":
+		m.chosen = append(m.chosen, m.queue[m.pos])
+		m.pos++
+	case "n":
+		m.pos++
+	case "s":
+		sub := splitHunk(m.queue[m.pos])
+		m.queue = append(m.queue[:m.pos], append(sub, m.queue[m.pos+1:]...)...)
+	case "q", "esc", "ctrl+c":
+		m.quit = true
+		return true
+	}
+	return m.pos >= len(m.queue)
+}
+
+// apply stages (or unstages, when m.unstage) whatever hunks were
+// confirmed with 'y', returning the patch that was applied (empty if
+// nothing was chosen) so the caller can record it for undo/redo.
+func (m *hunkPickerModel) apply() (string, error) {
+	if len(m.chosen) == 0 {
+		return "", nil
+	}
+	patch := buildPatch(m.preamble, m.chosen)
+	if err := applyPatch(patch, m.unstage); err != nil {
+		return "", err
+	}
+	return patch, nil
+}
+
+func (m *hunkPickerModel) View() string {
+	if m.pos >= len(m.queue) {
+		return ""
+	}
+	h := m.queue[m.pos]
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Hunk %d/%d — %s", m.pos+1, len(m.queue), m.path)) + "\n")
+	b.WriteString(h.header() + "\n")
+	for _, ln := range h.lines {
+		switch {
+		case strings.HasPrefix(ln, "+"):
+			b.WriteString(addStyle.Render(ln) + "\n")
+		case strings.HasPrefix(ln, "-"):
+			b.WriteString(delStyle.Render(ln) + "\n")
+		default:
+			b.WriteString(ln + "\n")
+		}
+	}
+	action := "stage"
+	if m.unstage {
+		action = "unstage"
+	}
+	b.WriteString(legendStyle.Render("y " + action + "  •  n skip  •  s split  •  q quit (applies decisions so far)"))
+	return b.String()
+}
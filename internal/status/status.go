@@ -0,0 +1,148 @@
+// Package status loads the merged git status + numstat view shared by
+// gitadd's interactive TUI and its non-interactive --format output.
+package status
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// File is one entry from `git status --porcelain`, enriched with the
+// added/deleted line counts (summed across staged + unstaged) for that
+// path from `git diff --numstat`.
+type File struct {
+	Path     string
+	Index    rune // index status (staged) – first column
+	Worktree rune // worktree status (unstaged) – second column
+	RawLine  string
+
+	Added   int
+	Deleted int
+	Binary  bool // true if numstat reports "-" (binary) for this path
+}
+
+// Load returns the repo's status + numstat rows merged into File values.
+// When scope is non-empty, every git invocation is restricted to that
+// subpath (via `-- scope`), for drilling into a subdirectory of large
+// repos.
+func Load(scope string) ([]File, error) {
+	files, err := gitStatus(scope)
+	if err != nil {
+		return nil, err
+	}
+	added, deleted, binary, err := gitNumstatTotals(scope)
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		p := files[i].Path
+		files[i].Added = added[p]
+		files[i].Deleted = deleted[p]
+		files[i].Binary = binary[p]
+	}
+	return files, nil
+}
+
+func gitStatus(scope string) ([]File, error) {
+	args := []string{"status", "--porcelain"}
+	if scope != "" {
+		args = append(args, "--", scope)
+	}
+	out, err := run("git", args...)
+	if err != nil {
+		return nil, fmt.Errorf("not a git repo or git error: %w", err)
+	}
+	lines := strings.Split(out, "\n")
+	var files []File
+	for _, ln := range lines {
+		if strings.TrimSpace(ln) == "" {
+			continue
+		}
+		if len(ln) < 3 {
+			continue
+		}
+		x := rune(ln[0])
+		y := rune(ln[1])
+		p := strings.TrimSpace(ln[3:])
+		if i := strings.LastIndex(p, " -> "); i >= 0 {
+			p = strings.TrimSpace(p[i+4:])
+		}
+		files = append(files, File{Path: p, Index: x, Worktree: y, RawLine: ln})
+	}
+	return files, nil
+}
+
+// gitNumstatTotals returns per-path totals for added/deleted lines,
+// summing both unstaged (worktree) and staged (index) diffs.
+// Also flags binaries (numstat prints "-" for either column).
+func gitNumstatTotals(scope string) (map[string]int, map[string]int, map[string]bool, error) {
+	add := map[string]int{}
+	del := map[string]int{}
+	bin := map[string]bool{}
+
+	scopeArgs := func(args ...string) []string {
+		if scope != "" {
+			args = append(args, "--", scope)
+		}
+		return args
+	}
+
+	// Unstaged (index..worktree)
+	if err := accumulateNumstat(add, del, bin, scopeArgs("diff", "--numstat")...); err != nil {
+		return nil, nil, nil, err
+	}
+	// Staged (HEAD..index)
+	if err := accumulateNumstat(add, del, bin, scopeArgs("diff", "--cached", "--numstat")...); err != nil {
+		return nil, nil, nil, err
+	}
+	return add, del, bin, nil
+}
+
+func accumulateNumstat(add, del map[string]int, bin map[string]bool, args ...string) error {
+	out, err := run("git", args...)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(out) == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	for _, ln := range lines {
+		// Format: <added>\t<deleted>\t<path>   (for renames path may be "old\tnew")
+		fields := strings.Split(ln, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		addStr := fields[0]
+		delStr := fields[1]
+		path := fields[len(fields)-1] // take the rightmost (new) path, handles renames
+
+		// Binary files show "-" in either column
+		isBin := addStr == "-" || delStr == "-"
+		if isBin {
+			bin[path] = true
+		}
+
+		if a, err := strconv.Atoi(addStr); err == nil {
+			add[path] += a
+		}
+		if d, err := strconv.Atoi(delStr); err == nil {
+			del[path] += d
+		}
+	}
+	return nil
+}
+
+func run(name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s: %v\n%s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -2,30 +2,26 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/DireLines/gitadd/internal/status"
+	"github.com/charmbracelet/bubbles/filepicker"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-type fileChange struct {
-	Path     string
-	Index    rune // index status (staged) – first column
-	Worktree rune // worktree status (unstaged) – second column
-	RawLine  string
-
-	// Diff totals across staged + unstaged
-	Added   int
-	Deleted int
-	Binary  bool // true if numstat reports "-" (binary) for this path
-}
+// fileChange is status.File plus the list.Item methods the TUI needs;
+// it's a defined (not aliased) type so those methods live here.
+type fileChange status.File
 
 func (f fileChange) Title() string       { return f.Path }
 func (f fileChange) Description() string { return "" }
@@ -107,14 +103,59 @@ func (d oneLineDelegate) Render(w io.Writer, m list.Model, index int, it list.It
 
 // --- program state ---
 
+// screenMode selects which full-screen view bubbleteaModel.Update/View
+// delegate to; the list is the default and every other screen returns
+// to it when it finishes.
+type screenMode int
+
+const (
+	modeList screenMode = iota
+	modeHunkPicker
+	modeCommit
+	modeFilePicker
+	modeGlobFilter
+)
+
 type bubbleteaModel struct {
 	l            list.Model
 	confirmInput textinput.Model
 	err          error
+	watcher      *fsWatcher
+	diff         diffViewport
+	width        int
+	height       int
+
+	mode       screenMode
+	hunkPicker *hunkPickerModel
+	commit     *commitModel
+	picker     filepicker.Model
+
+	scope       string
+	filter      filterSpec
+	filterInput textinput.Model
+
+	undo undoStack
 }
 
 func main() {
-	files, err := loadFilesWithNumstat()
+	format := flag.String("format", "", "skip the interactive TUI and print status as json, porcelain, or tsv")
+	scope := flag.String("scope", "", "restrict status/diff/add/reset to this subpath of the worktree")
+	flag.Parse()
+
+	if *format != "" {
+		files, err := status.Load(*scope)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gitadd:", err)
+			os.Exit(1)
+		}
+		if err := writeFormatted(os.Stdout, *format, files); err != nil {
+			fmt.Fprintln(os.Stderr, "gitadd:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	files, err := loadFilesWithNumstat(*scope)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "gitadd:", err)
 		os.Exit(1)
@@ -128,29 +169,68 @@ func main() {
 	l.SetShowHelp(false)
 	l.SetShowTitle(false)
 	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(true)
+	// Fuzzy filtering is superseded by our own glob include/exclude
+	// filter (bound to "/"); leave list filtering off so the key isn't
+	// double-booked.
+	l.SetFilteringEnabled(false)
 
 	ti := textinput.New()
 	ti.Placeholder = "Type 'y' to confirm"
 	ti.CharLimit = 1
 	ti.Prompt = "Discard working changes? (y/N): "
 
-	m := bubbleteaModel{l: l, confirmInput: ti}
+	m := bubbleteaModel{l: l, confirmInput: ti, diff: newDiffViewport(), scope: *scope}
+	m.updateTitle()
+	if watcher, err := newFSWatcher("."); err != nil {
+		fmt.Fprintln(os.Stderr, "gitadd: warning: filesystem watch disabled:", err)
+	} else {
+		m.watcher = watcher
+	}
 	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
 		fmt.Fprintln(os.Stderr, "gitadd:", err)
 		os.Exit(1)
 	}
 }
 
-func (m bubbleteaModel) Init() tea.Cmd { return nil }
+func (m bubbleteaModel) Init() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	return m.watcher.waitForChange()
+}
 
 func (m bubbleteaModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.mode == modeHunkPicker {
+		return m.updateHunkPicker(msg)
+	}
+	if m.mode == modeCommit {
+		return m.updateCommit(msg)
+	}
+	if m.mode == modeFilePicker {
+		return m.updateFilePicker(msg)
+	}
+	if m.mode == modeGlobFilter {
+		return m.updateGlobFilter(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.l.SetSize(msg.Width, msg.Height-4)
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+	case fsChangedMsg:
+		m.undo.invalidateIfStale()
+		newM, _ := m.refresh()
+		m = newM.(bubbleteaModel)
+		if m.watcher == nil {
+			return m, nil
+		}
+		return m, m.watcher.waitForChange()
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "q", "esc":
+			if m.watcher != nil {
+				m.watcher.Close()
+			}
 			return m, tea.Quit
 		case "r":
 			return m.refresh()
@@ -160,27 +240,267 @@ func (m bubbleteaModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.unstageSelected()
 		case "a":
 			return m.stageAll()
-		case "u":
+		case "U":
 			return m.unstageAll()
+		case "u":
+			return m.undoLast()
+		case "ctrl+r":
+			return m.redoLast()
+		case "d":
+			m.diff.visible = !m.diff.visible
+			m.resize()
+			if m.diff.visible {
+				m.diff.loadFor(m.currentItem())
+			}
+			return m, nil
+		case "pgup", "pgdown":
+			if m.diff.visible {
+				var cmd tea.Cmd
+				m.diff.vp, cmd = m.diff.vp.Update(msg)
+				return m, cmd
+			}
+		case "p":
+			return m.openHunkPicker(false)
+		case "P":
+			return m.openHunkPicker(true)
+		case "c":
+			return m.openCommit(false)
+		case "C":
+			return m.openCommit(true)
+		case "s":
+			return m.openFilePicker()
+		case "/":
+			return m.openGlobFilter()
 		}
 	}
 
 	var cmd tea.Cmd
 	m.l, cmd = m.l.Update(msg)
+	if m.diff.visible {
+		m.diff.loadFor(m.currentItem())
+	}
 	return m, cmd
 }
 
+// resize lays out the list (and, when toggled on, the diff viewport)
+// side by side within the current terminal size.
+func (m *bubbleteaModel) resize() {
+	listHeight := m.height - 4
+	if !m.diff.visible {
+		m.l.SetSize(m.width, listHeight)
+		return
+	}
+	listWidth := m.width / 2
+	diffWidth := m.width - listWidth
+	m.l.SetSize(listWidth, listHeight)
+	m.diff.vp.Width = diffWidth
+	m.diff.vp.Height = listHeight
+}
+
+// openHunkPicker enters the hunk-level staging screen for the focused
+// file. By default, unstaged changes are picked for staging, falling
+// back to staged changes for unstaging only when there's nothing
+// unstaged left to pick from; forceUnstage (bound to "P") always opens
+// the staged side instead, so a partially-staged file — both an index
+// and a worktree component — can still have its staged hunks unstaged
+// one at a time instead of always landing on the worktree side.
+func (m bubbleteaModel) openHunkPicker(forceUnstage bool) (tea.Model, tea.Cmd) {
+	f := m.currentItem()
+	if f == nil {
+		return m, nil
+	}
+	unstage := forceUnstage || (f.Worktree == ' ' && f.Index != ' ')
+	picker, err := newHunkPicker(f.Path, unstage)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(picker.queue) == 0 {
+		return m, nil
+	}
+	m.hunkPicker = picker
+	m.mode = modeHunkPicker
+	return m, nil
+}
+
+func (m bubbleteaModel) updateHunkPicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	finished := m.hunkPicker.handleKey(keyMsg.String())
+	if !finished {
+		return m, nil
+	}
+	patch, err := m.hunkPicker.apply()
+	if err != nil {
+		m.err = err
+	} else if patch != "" {
+		kind := opStage
+		if m.hunkPicker.unstage {
+			kind = opUnstage
+		}
+		m.undo.recordPatch(kind, []string{m.hunkPicker.path}, patch)
+	}
+	m.hunkPicker = nil
+	m.mode = modeList
+	return m.refresh()
+}
+
+// openCommit enters the commit composer; amend prefills it with the
+// last commit's message and recommits with --amend on submit.
+func (m bubbleteaModel) openCommit(amend bool) (tea.Model, tea.Cmd) {
+	cm, err := newCommitModel(stagedFiles(m.l.Items()), amend)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.commit = cm
+	m.mode = modeCommit
+	return m, nil
+}
+
+func (m bubbleteaModel) updateCommit(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	done, committed := m.commit.handleKey(keyMsg)
+	if !done {
+		return m, nil
+	}
+	if committed {
+		if err := m.commit.commit(); err != nil {
+			m.commit.err = err
+			return m, nil
+		}
+		// A commit moves HEAD, so any recorded index ops are no longer
+		// meaningful to undo/redo against.
+		m.undo.clear()
+	}
+	m.commit = nil
+	m.mode = modeList
+	return m.refresh()
+}
+
+// openFilePicker enters a filepicker rooted at the worktree (or the
+// current scope) so the user can drill into a subdirectory.
+func (m bubbleteaModel) openFilePicker() (tea.Model, tea.Cmd) {
+	fp := filepicker.New()
+	fp.DirAllowed = true
+	fp.FileAllowed = false
+	fp.Height = m.height - 6
+	wd, err := os.Getwd()
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fp.CurrentDirectory = wd
+	if m.scope != "" {
+		fp.CurrentDirectory = filepath.Join(wd, m.scope)
+	}
+	m.picker = fp
+	m.mode = modeFilePicker
+	return m, m.picker.Init()
+}
+
+func (m bubbleteaModel) updateFilePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "esc" {
+		m.mode = modeList
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Update(msg)
+	if didSelect, path := m.picker.DidSelectFile(msg); didSelect {
+		wd, err := os.Getwd()
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		rel, err := filepath.Rel(wd, path)
+		if err != nil {
+			rel = path
+		}
+		m.scope = rel
+		m.updateTitle()
+		m.mode = modeList
+		return m.refresh()
+	}
+	return m, cmd
+}
+
+// openGlobFilter enters a prompt for comma-separated include/exclude
+// glob patterns (e.g. "*.go, !vendor/**") applied to the in-memory list.
+func (m bubbleteaModel) openGlobFilter() (tea.Model, tea.Cmd) {
+	ti := textinput.New()
+	ti.Placeholder = "*.go, !vendor/**"
+	ti.SetValue(m.filter.raw)
+	ti.Focus()
+	m.filterInput = ti
+	m.mode = modeGlobFilter
+	return m, nil
+}
+
+func (m bubbleteaModel) updateGlobFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "enter":
+		m.filter = parseFilterSpec(m.filterInput.Value())
+		m.updateTitle()
+		m.mode = modeList
+		return m.refresh()
+	case "esc":
+		m.mode = modeList
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	return m, cmd
+}
+
+// updateTitle reflects the active scope in the list title so users
+// don't lose track of which subdirectory they've drilled into.
+func (m *bubbleteaModel) updateTitle() {
+	title := "gitadd — interactive add/reset"
+	if m.scope != "" {
+		title += fmt.Sprintf(" [%s]", m.scope)
+	}
+	if !m.filter.empty() {
+		title += fmt.Sprintf(" (filter: %s)", m.filter.raw)
+	}
+	m.l.Title = title
+}
+
 func (m bubbleteaModel) View() string {
+	if m.mode == modeHunkPicker {
+		return m.hunkPicker.View()
+	}
+	if m.mode == modeCommit {
+		return m.commit.View()
+	}
+	if m.mode == modeFilePicker {
+		return titleStyle.Render("Choose a subdirectory to scope to (esc to cancel)") + "\n" + m.picker.View()
+	}
+	if m.mode == modeGlobFilter {
+		return titleStyle.Render("Filter by glob (enter to apply, esc to cancel)") + "\n" + m.filterInput.View()
+	}
 	var b strings.Builder
 	b.WriteString(titleStyle.Render(m.l.Title) + "\n")
-	b.WriteString(m.l.View())
+	if m.diff.visible {
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, m.l.View(), m.diff.vp.View()))
+	} else {
+		b.WriteString(m.l.View())
+	}
 	b.WriteString("\n")
 	if m.err != nil {
 		b.WriteString(errorStyle.Render("Error: " + m.err.Error()))
 		b.WriteString("\n")
 	}
 	b.WriteString(legendStyle.Render(
-		"↑/↓ move  •  ← unstage  •  → stage  •  a stage all  •  u unstage all  •  r refresh  •  q quit\n" +
+		fmt.Sprintf("↑/↓ move  •  ← unstage  •  → stage  •  a stage all  •  U unstage all  •  u undo  •  ctrl+r redo (%d/%d)  •  p hunks  •  P unstage hunks  •  d diff  •  c commit  •  C amend  •  s scope  •  / filter  •  r refresh  •  q quit\n", m.undo.pos, len(m.undo.ops)) +
 			"[Index|Work] legend: M=modified, A=added, D=deleted, R=renamed, C=copied, U=updated, ?=untracked, -=clean  •  counts show total +adds/-dels",
 	))
 	return b.String()
@@ -197,18 +517,38 @@ func (m *bubbleteaModel) currentItem() *fileChange {
 }
 
 func (m bubbleteaModel) refresh() (tea.Model, tea.Cmd) {
-	files, err := loadFilesWithNumstat()
+	files, err := loadFilesWithNumstat(m.scope)
 	if err != nil {
 		m.err = err
 		return m, nil
 	}
+	files = filterFiles(files, m.filter)
 	m.l.SetItems(toBubbleteaItems(files))
 	if m.l.Index() >= len(m.l.Items()) {
 		m.l.Select(len(m.l.Items()) - 1)
 	}
+	if m.diff.visible {
+		m.diff.loadFor(m.currentItem())
+	}
 	return m, nil
 }
 
+func (m bubbleteaModel) undoLast() (tea.Model, tea.Cmd) {
+	if err := m.undo.undo(); err != nil {
+		m.err = err
+		return m, nil
+	}
+	return m.refresh()
+}
+
+func (m bubbleteaModel) redoLast() (tea.Model, tea.Cmd) {
+	if err := m.undo.redo(); err != nil {
+		m.err = err
+		return m, nil
+	}
+	return m.refresh()
+}
+
 func (m bubbleteaModel) stageSelected() (tea.Model, tea.Cmd) {
 	item := m.currentItem()
 	if item == nil {
@@ -218,6 +558,7 @@ func (m bubbleteaModel) stageSelected() (tea.Model, tea.Cmd) {
 		m.err = err
 		return m, nil
 	}
+	m.undo.record(opStage, []string{item.Path})
 	return m.refresh()
 }
 
@@ -230,6 +571,7 @@ func (m bubbleteaModel) unstageSelected() (tea.Model, tea.Cmd) {
 		m.err = err
 		return m, nil
 	}
+	m.undo.record(opUnstage, []string{item.Path})
 	return m.refresh()
 }
 
@@ -242,6 +584,7 @@ func (m bubbleteaModel) stageAll() (tea.Model, tea.Cmd) {
 		m.err = err
 		return m, nil
 	}
+	m.undo.record(opStage, paths)
 	return m.refresh()
 }
 
@@ -254,108 +597,24 @@ func (m bubbleteaModel) unstageAll() (tea.Model, tea.Cmd) {
 		m.err = err
 		return m, nil
 	}
+	m.undo.record(opUnstage, paths)
 	return m.refresh()
 }
 
 // -------- git helpers --------
 
-// loadFilesWithNumstat: status + numstat merged into fileChange rows
-func loadFilesWithNumstat() ([]fileChange, error) {
-	files, err := gitStatus()
+// loadFilesWithNumstat loads the shared status+numstat view and adapts
+// it into the fileChange rows the TUI's list.Model renders.
+func loadFilesWithNumstat(scope string) ([]fileChange, error) {
+	files, err := status.Load(scope)
 	if err != nil {
 		return nil, err
 	}
-	added, deleted, binary, err := gitNumstatTotals()
-	if err != nil {
-		return nil, err
+	out := make([]fileChange, len(files))
+	for i, f := range files {
+		out[i] = fileChange(f)
 	}
-	for i := range files {
-		p := files[i].Path
-		files[i].Added = added[p]
-		files[i].Deleted = deleted[p]
-		files[i].Binary = binary[p]
-	}
-	return files, nil
-}
-
-func gitStatus() ([]fileChange, error) {
-	out, err := run("git", "status", "--porcelain")
-	if err != nil {
-		return nil, fmt.Errorf("not a git repo or git error: %w", err)
-	}
-	lines := strings.Split(out, "\n")
-	var files []fileChange
-	for _, ln := range lines {
-		if strings.TrimSpace(ln) == "" {
-			continue
-		}
-		if len(ln) < 3 {
-			continue
-		}
-		x := rune(ln[0])
-		y := rune(ln[1])
-		p := strings.TrimSpace(ln[3:])
-		if i := strings.LastIndex(p, " -> "); i >= 0 {
-			p = strings.TrimSpace(p[i+4:])
-		}
-		files = append(files, fileChange{Path: p, Index: x, Worktree: y, RawLine: ln})
-	}
-	return files, nil
-}
-
-// gitNumstatTotals returns per-path totals for added/deleted lines,
-// summing both unstaged (worktree) and staged (index) diffs.
-// Also flags binaries (numstat prints "-" for either column).
-func gitNumstatTotals() (map[string]int, map[string]int, map[string]bool, error) {
-	add := map[string]int{}
-	del := map[string]int{}
-	bin := map[string]bool{}
-
-	// Unstaged (index..worktree)
-	if err := accumulateNumstat(add, del, bin, "diff", "--numstat"); err != nil {
-		return nil, nil, nil, err
-	}
-	// Staged (HEAD..index)
-	if err := accumulateNumstat(add, del, bin, "diff", "--cached", "--numstat"); err != nil {
-		return nil, nil, nil, err
-	}
-	return add, del, bin, nil
-}
-
-func accumulateNumstat(add, del map[string]int, bin map[string]bool, args ...string) error {
-	out, err := run("git", args...)
-	if err != nil {
-		return err
-	}
-	if strings.TrimSpace(out) == "" {
-		return nil
-	}
-	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
-	for _, ln := range lines {
-		// Format: <added>\t<deleted>\t<path>   (for renames path may be "old\tnew")
-		fields := strings.Split(ln, "\t")
-		if len(fields) < 3 {
-			continue
-		}
-		addStr := fields[0]
-		delStr := fields[1]
-		path := fields[len(fields)-1] // take the rightmost (new) path, handles renames
-
-		// Binary files show "-" in either column
-		isBin := addStr == "-" || delStr == "-"
-		if isBin {
-			bin[path] = true
-			// We still try to add counts if one side is numeric, but usually "-" on both.
-		}
-
-		if a, err := strconv.Atoi(addStr); err == nil {
-			add[path] += a
-		}
-		if d, err := strconv.Atoi(delStr); err == nil {
-			del[path] += d
-		}
-	}
-	return nil
+	return out, nil
 }
 
 func gitAdd(paths ...string) error {
@@ -383,6 +642,20 @@ func run(name string, args ...string) (string, error) {
 	return stdout.String(), nil
 }
 
+// runWithStdin is like run but pipes stdin into the command, for
+// subcommands like `git commit -F -` that read their message from it.
+func runWithStdin(stdin, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Stdin = strings.NewReader(stdin)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s: %v\n%s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
 // -------- list item helpers --------
 
 func toBubbleteaItems(files []fileChange) []list.Item {
@@ -393,6 +666,16 @@ func toBubbleteaItems(files []fileChange) []list.Item {
 	return items
 }
 
+func stagedFiles(items []list.Item) []fileChange {
+	var out []fileChange
+	for _, it := range items {
+		if f, ok := it.(fileChange); ok && f.Index != ' ' {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
 func itemsPaths(items []list.Item) []string {
 	var out []string
 	for _, it := range items {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// filterSpec is a set of include/exclude glob patterns applied to the
+// in-memory file list, e.g. "*.go, !vendor/**".
+type filterSpec struct {
+	raw      string
+	includes []string
+	excludes []string
+}
+
+// parseFilterSpec splits a comma-separated pattern list into includes
+// and excludes (patterns prefixed with "!").
+func parseFilterSpec(raw string) filterSpec {
+	spec := filterSpec{raw: raw}
+	for _, p := range strings.Split(raw, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if strings.HasPrefix(p, "!") {
+			spec.excludes = append(spec.excludes, strings.TrimPrefix(p, "!"))
+		} else {
+			spec.includes = append(spec.includes, p)
+		}
+	}
+	return spec
+}
+
+func (s filterSpec) empty() bool {
+	return len(s.includes) == 0 && len(s.excludes) == 0
+}
+
+func (s filterSpec) matches(path string) bool {
+	if len(s.includes) > 0 {
+		included := false
+		for _, pat := range s.includes {
+			if patternMatches(pat, path) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, pat := range s.excludes {
+		if patternMatches(pat, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// patternMatches reports whether pat matches path, treating a bare
+// pattern with no "/" (e.g. "*.go") the way gitignore does: matching at
+// any depth, not just the repo root. doublestar.Match alone would only
+// match "*.go" against top-level files, which isn't what "stage by
+// language" across a monorepo means.
+func patternMatches(pat, path string) bool {
+	if ok, _ := doublestar.Match(pat, path); ok {
+		return true
+	}
+	if !strings.Contains(pat, "/") {
+		if ok, _ := doublestar.Match("**/"+pat, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterFiles applies spec to files, so users can stage-batch by
+// language or module without leaving the TUI.
+func filterFiles(files []fileChange, spec filterSpec) []fileChange {
+	if spec.empty() {
+		return files
+	}
+	out := make([]fileChange, 0, len(files))
+	for _, f := range files {
+		if spec.matches(f.Path) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
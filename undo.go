@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+type opKind int
+
+const (
+	opStage opKind = iota
+	opUnstage
+)
+
+// op is one recorded staging change. Whole-file stage/unstage calls
+// (patch == "") are invertible by running the opposite gitAdd/gitUnstage
+// command against the same paths. Hunk-level changes additionally carry
+// the exact patch that was applied to (or reversed from) the index, so
+// undo/redo can replay that same patch instead of falling back to a
+// whole-file command that would restage or discard hunks the user never
+// touched.
+type op struct {
+	kind  opKind
+	paths []string
+	patch string
+}
+
+const maxUndoDepth = 100
+
+// undoStack is an in-memory log of staging operations. ops[:pos] is the
+// undo-able history; ops[pos:] are operations undone and available to
+// redo. stateHash is the git-status fingerprint the stack assumes;
+// external changes that the filesystem watcher reports (editor saves,
+// formatters) invalidate it rather than let undo act on state it didn't
+// cause. Changes confined to .git raise no watcher event.
+type undoStack struct {
+	ops       []op
+	pos       int
+	stateHash string
+}
+
+func statusHash() (string, error) {
+	out, err := run("git", "status", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(out))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// record appends a new whole-file op, dropping any redo history after it
+// and capping the stack at maxUndoDepth entries.
+func (s *undoStack) record(kind opKind, paths []string) {
+	s.recordPatch(kind, paths, "")
+}
+
+// recordPatch is like record, but for a hunk-level stage/unstage: patch
+// is the exact unified diff that was applied (forward for a stage,
+// reversed for an unstage), so undo/redo can replay just that hunk
+// instead of the whole file.
+func (s *undoStack) recordPatch(kind opKind, paths []string, patch string) {
+	s.ops = append(s.ops[:s.pos], op{kind: kind, paths: paths, patch: patch})
+	s.pos++
+	if over := s.pos - maxUndoDepth; over > 0 {
+		s.ops = s.ops[over:]
+		s.pos -= over
+	}
+	if h, err := statusHash(); err == nil {
+		s.stateHash = h
+	}
+}
+
+// clear drops the whole undo/redo history, e.g. after a commit moves
+// HEAD and makes the recorded index ops meaningless to replay.
+func (s *undoStack) clear() {
+	*s = undoStack{}
+}
+
+func (s *undoStack) canUndo() bool { return s.pos > 0 }
+func (s *undoStack) canRedo() bool { return s.pos < len(s.ops) }
+
+func (s *undoStack) undo() error {
+	if !s.canUndo() {
+		return nil
+	}
+	o := s.ops[s.pos-1]
+	var err error
+	switch {
+	case o.patch != "":
+		// The op's forward action applied patch with reverse=true iff
+		// it was an unstage; undo replays patch in the opposite
+		// direction to restore exactly the hunk(s) it touched.
+		err = applyPatch(o.patch, o.kind != opUnstage)
+	case o.kind == opStage:
+		err = gitUnstage(o.paths...)
+	default:
+		err = gitAdd(o.paths...)
+	}
+	if err != nil {
+		return err
+	}
+	s.pos--
+	if h, herr := statusHash(); herr == nil {
+		s.stateHash = h
+	}
+	return nil
+}
+
+func (s *undoStack) redo() error {
+	if !s.canRedo() {
+		return nil
+	}
+	o := s.ops[s.pos]
+	var err error
+	switch {
+	case o.patch != "":
+		err = applyPatch(o.patch, o.kind == opUnstage)
+	case o.kind == opStage:
+		err = gitAdd(o.paths...)
+	default:
+		err = gitUnstage(o.paths...)
+	}
+	if err != nil {
+		return err
+	}
+	s.pos++
+	if h, herr := statusHash(); herr == nil {
+		s.stateHash = h
+	}
+	return nil
+}
+
+// invalidateIfStale drops the whole stack once the repo's status no
+// longer matches the hash recorded after the last op, since an
+// external change means undo/redo offsets can't be trusted.
+func (s *undoStack) invalidateIfStale() {
+	if s.stateHash == "" {
+		return
+	}
+	h, err := statusHash()
+	if err != nil || h == s.stateHash {
+		return
+	}
+	*s = undoStack{}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// fsChangedMsg is sent once a burst of filesystem events has settled,
+// telling the program it's safe to refresh() the file list.
+type fsChangedMsg struct{}
+
+const fsDebounce = 200 * time.Millisecond
+
+// fsWatcher recursively watches a repo root for changes outside .git,
+// collapsing bursts of events (editor saves, formatters) into a single
+// debounced fsChangedMsg. Changes that only touch .git (such as a
+// git add or git reset) raise no event.
+type fsWatcher struct {
+	w      *fsnotify.Watcher
+	events chan tea.Msg
+	done   chan struct{}
+}
+
+func newFSWatcher(root string) (*fsWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	fw := &fsWatcher{w: w, events: make(chan tea.Msg, 1), done: make(chan struct{})}
+	if err := fw.addRecursive(root); err != nil {
+		w.Close()
+		return nil, err
+	}
+	go fw.debounceLoop()
+	return fw, nil
+}
+
+func (fw *fsWatcher) addRecursive(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if shouldSkipPath(path) {
+			return filepath.SkipDir
+		}
+		return fw.w.Add(path)
+	})
+}
+
+// shouldSkipPath keeps .git/ (and anything git itself ignores) out of the
+// watch set and out of the events that trigger a refresh, so our own git
+// add/reset calls (and gitignored build artifacts sitting inside an
+// otherwise-tracked directory) don't cause feedback loops or spurious
+// refreshes.
+func shouldSkipPath(path string) bool {
+	if filepath.Base(path) == ".git" {
+		return true
+	}
+	if _, err := run("git", "check-ignore", "-q", path); err == nil {
+		return true
+	}
+	return false
+}
+
+func (fw *fsWatcher) debounceLoop() {
+	var timer *time.Timer
+	fire := make(chan struct{}, 1)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		select {
+		case ev, ok := <-fw.w.Events:
+			if !ok {
+				return
+			}
+			if strings.Contains(ev.Name, string(filepath.Separator)+".git"+string(filepath.Separator)) {
+				continue
+			}
+			if ev.Op&fsnotify.Create != 0 {
+				// fsnotify doesn't recurse: a directory created after
+				// the watch started needs to be added explicitly, or
+				// files that later land inside it go unnoticed.
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					fw.addRecursive(ev.Name)
+				}
+			}
+			if shouldSkipPath(ev.Name) {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(fsDebounce, func() {
+					select {
+					case fire <- struct{}{}:
+					default:
+					}
+				})
+			} else {
+				timer.Reset(fsDebounce)
+			}
+		case <-fire:
+			select {
+			case fw.events <- fsChangedMsg{}:
+			default:
+			}
+		case _, ok := <-fw.w.Errors:
+			if !ok {
+				return
+			}
+		case <-fw.done:
+			return
+		}
+	}
+}
+
+// waitForChange returns a tea.Cmd that blocks until the next debounced
+// fsChangedMsg; call it again after handling one to keep listening.
+func (fw *fsWatcher) waitForChange() tea.Cmd {
+	return func() tea.Msg {
+		return <-fw.events
+	}
+}
+
+func (fw *fsWatcher) Close() error {
+	close(fw.done)
+	return fw.w.Close()
+}